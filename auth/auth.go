@@ -0,0 +1,101 @@
+// Package auth provides an OAuth2 client for flows that the API-key
+// transport cannot reach (comment moderation, private videos,
+// quota-per-user endpoints).
+package auth
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"os"
+	"path/filepath"
+
+	"golang.org/x/oauth2"
+)
+
+const (
+	credentialsDirName = ".credentials"
+	tokenFileName      = "go-ytb-comments.json"
+)
+
+// GetClient returns an HTTP client authorized with the given OAuth2 config.
+// It reuses a cached token from $HOME/.credentials/go-ytb-comments.json
+// when available, otherwise it prompts the user to authorize in a browser
+// and caches the resulting token for next time.
+func GetClient(ctx context.Context, config *oauth2.Config) (*http.Client, error) {
+	tokenFile, err := tokenCachePath()
+	if err != nil {
+		return nil, fmt.Errorf("unable to resolve token cache path: %w", err)
+	}
+
+	token, err := tokenFromFile(tokenFile)
+	if err != nil {
+		token, err = tokenFromWeb(ctx, config)
+		if err != nil {
+			return nil, fmt.Errorf("unable to get token: %w", err)
+		}
+
+		if err := saveToken(tokenFile, token); err != nil {
+			return nil, fmt.Errorf("unable to cache token: %w", err)
+		}
+	}
+
+	return config.Client(ctx, token), nil
+}
+
+func tokenCachePath() (string, error) {
+	home, err := os.UserHomeDir()
+	if err != nil {
+		return "", err
+	}
+
+	dir := filepath.Join(home, credentialsDirName)
+	if err := os.MkdirAll(dir, 0700); err != nil {
+		return "", err
+	}
+
+	return filepath.Join(dir, tokenFileName), nil
+}
+
+func tokenFromFile(file string) (*oauth2.Token, error) {
+	f, err := os.Open(file)
+	if err != nil {
+		return nil, err
+	}
+	defer f.Close()
+
+	token := &oauth2.Token{}
+	if err := json.NewDecoder(f).Decode(token); err != nil {
+		return nil, err
+	}
+
+	return token, nil
+}
+
+func saveToken(file string, token *oauth2.Token) error {
+	f, err := os.OpenFile(file, os.O_RDWR|os.O_CREATE|os.O_TRUNC, 0600)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	return json.NewEncoder(f).Encode(token)
+}
+
+func tokenFromWeb(ctx context.Context, config *oauth2.Config) (*oauth2.Token, error) {
+	authURL := config.AuthCodeURL("state-token", oauth2.AccessTypeOffline)
+	fmt.Printf("Go to the following link in your browser then type the authorization code:\n%v\n", authURL)
+
+	var code string
+	if _, err := fmt.Scan(&code); err != nil {
+		return nil, fmt.Errorf("unable to read authorization code: %w", err)
+	}
+
+	token, err := config.Exchange(ctx, code)
+	if err != nil {
+		return nil, fmt.Errorf("unable to exchange authorization code for token: %w", err)
+	}
+
+	return token, nil
+}
@@ -0,0 +1,16 @@
+// Package comments models a YouTube comment thread, including replies,
+// and writes it out in the format downstream tooling expects.
+package comments
+
+import "time"
+
+// Comment is a single top-level comment or reply, with its replies (if
+// any) nested underneath.
+type Comment struct {
+	ID          string    `json:"id"`
+	Author      string    `json:"author"`
+	Text        string    `json:"text"`
+	PublishedAt time.Time `json:"publishedAt"`
+	LikeCount   int64     `json:"likeCount"`
+	Replies     []Comment `json:"replies,omitempty"`
+}
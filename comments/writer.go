@@ -0,0 +1,153 @@
+package comments
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"io"
+	"strconv"
+)
+
+// Writer renders a slice of top-level comments (with nested replies) to w.
+type Writer interface {
+	Write(w io.Writer, comments []Comment) error
+
+	// Incremental reports whether Write can safely be called multiple
+	// times in succession, each call emitting only the comments newly
+	// fetched since the last call (as fetchAndWriteComments does while
+	// paginating). It is true for line-oriented formats (txt, jsonl),
+	// where each call appends independently valid output. It is false
+	// for formats where only a single call over the complete set
+	// produces a well-formed document (json's one top-level array,
+	// csv's single header row) - callers must buffer and write those
+	// once.
+	Incremental() bool
+}
+
+// WriterForFormat returns the Writer for the given --format value. An
+// empty string selects the default text writer.
+func WriterForFormat(format string) (Writer, error) {
+	switch format {
+	case "", "txt":
+		return TextWriter{}, nil
+	case "json":
+		return JSONWriter{}, nil
+	case "jsonl":
+		return JSONLWriter{}, nil
+	case "csv":
+		return CSVWriter{}, nil
+	default:
+		return nil, fmt.Errorf("unsupported format %q", format)
+	}
+}
+
+// TextWriter reproduces the original plain-text output, indenting replies
+// under their parent comment.
+type TextWriter struct{}
+
+func (TextWriter) Write(w io.Writer, comments []Comment) error {
+	for _, comment := range comments {
+		if err := writeTextComment(w, comment, 0); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func writeTextComment(w io.Writer, comment Comment, depth int) error {
+	prefix := ""
+	for i := 0; i < depth; i++ {
+		prefix += "  "
+	}
+
+	if _, err := fmt.Fprintf(w, "%sComment from %s: %s\n", prefix, comment.Author, comment.Text); err != nil {
+		return err
+	}
+
+	for _, reply := range comment.Replies {
+		if err := writeTextComment(w, reply, depth+1); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func (TextWriter) Incremental() bool { return true }
+
+// JSONWriter writes the full comment tree as a single indented JSON array.
+// Write must be called once with the complete set of comments: calling it
+// more than once emits a separate top-level array each time, which does
+// not concatenate into valid JSON.
+type JSONWriter struct{}
+
+func (JSONWriter) Write(w io.Writer, comments []Comment) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(comments)
+}
+
+func (JSONWriter) Incremental() bool { return false }
+
+// JSONLWriter writes one JSON object per top-level comment (with its
+// replies nested inside), one per line.
+type JSONLWriter struct{}
+
+func (JSONLWriter) Write(w io.Writer, comments []Comment) error {
+	encoder := json.NewEncoder(w)
+	for _, comment := range comments {
+		if err := encoder.Encode(comment); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (JSONLWriter) Incremental() bool { return true }
+
+// CSVWriter flattens the comment tree into rows, one per comment or
+// reply, with a parentId column linking replies back to their thread.
+// Write must be called once with the complete set of comments: calling it
+// more than once re-emits the header row mid-file.
+type CSVWriter struct{}
+
+func (CSVWriter) Write(w io.Writer, comments []Comment) error {
+	writer := csv.NewWriter(w)
+	defer writer.Flush()
+
+	if err := writer.Write([]string{"id", "parentId", "author", "text", "publishedAt", "likeCount"}); err != nil {
+		return err
+	}
+
+	for _, comment := range comments {
+		if err := writeCSVComment(writer, comment, ""); err != nil {
+			return err
+		}
+	}
+
+	return writer.Error()
+}
+
+func (CSVWriter) Incremental() bool { return false }
+
+func writeCSVComment(writer *csv.Writer, comment Comment, parentID string) error {
+	row := []string{
+		comment.ID,
+		parentID,
+		comment.Author,
+		comment.Text,
+		comment.PublishedAt.Format("2006-01-02T15:04:05Z07:00"),
+		strconv.FormatInt(comment.LikeCount, 10),
+	}
+	if err := writer.Write(row); err != nil {
+		return err
+	}
+
+	for _, reply := range comment.Replies {
+		if err := writeCSVComment(writer, reply, comment.ID); err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
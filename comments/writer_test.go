@@ -0,0 +1,81 @@
+package comments
+
+import (
+	"bytes"
+	"strings"
+	"testing"
+	"time"
+)
+
+func sampleComments() []Comment {
+	published := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	return []Comment{
+		{
+			ID:          "c1",
+			Author:      "Alice",
+			Text:        "Great video!",
+			PublishedAt: published,
+			LikeCount:   3,
+			Replies: []Comment{
+				{ID: "c1-r1", Author: "Bob", Text: "Agreed", PublishedAt: published, LikeCount: 1},
+			},
+		},
+	}
+}
+
+func TestWriterForFormat(t *testing.T) {
+	for _, format := range []string{"", "txt", "json", "jsonl", "csv"} {
+		if _, err := WriterForFormat(format); err != nil {
+			t.Errorf("WriterForFormat(%q) returned unexpected error: %v", format, err)
+		}
+	}
+
+	if _, err := WriterForFormat("xml"); err == nil {
+		t.Error("WriterForFormat(\"xml\") expected an error, got nil")
+	}
+}
+
+func TestTextWriterIndentsReplies(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (TextWriter{}).Write(&buf, sampleComments()); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "Comment from Alice: Great video!\n") {
+		t.Errorf("missing top-level comment in output: %q", got)
+	}
+	if !strings.Contains(got, "  Comment from Bob: Agreed\n") {
+		t.Errorf("missing indented reply in output: %q", got)
+	}
+}
+
+func TestIncremental(t *testing.T) {
+	cases := []struct {
+		writer Writer
+		want   bool
+	}{
+		{TextWriter{}, true},
+		{JSONLWriter{}, true},
+		{JSONWriter{}, false},
+		{CSVWriter{}, false},
+	}
+
+	for _, c := range cases {
+		if got := c.writer.Incremental(); got != c.want {
+			t.Errorf("%T.Incremental() = %v, want %v", c.writer, got, c.want)
+		}
+	}
+}
+
+func TestCSVWriterLinksRepliesToParent(t *testing.T) {
+	var buf bytes.Buffer
+	if err := (CSVWriter{}).Write(&buf, sampleComments()); err != nil {
+		t.Fatalf("Write returned unexpected error: %v", err)
+	}
+
+	got := buf.String()
+	if !strings.Contains(got, "c1-r1,c1,Bob") {
+		t.Errorf("expected reply row to reference parent id, got: %q", got)
+	}
+}
@@ -3,9 +3,9 @@ package main
 import (
 	"bufio"
 	"context"
+	"flag"
 	"fmt"
 	"net/http"
-	"net/url"
 	"os"
 	"strconv"
 	"strings"
@@ -15,9 +15,17 @@ import (
 	"github.com/cenkalti/backoff/v4"
 	"github.com/fatih/color"
 	"github.com/spf13/viper"
+	"golang.org/x/oauth2/google"
 	"golang.org/x/time/rate"
 	"google.golang.org/api/googleapi/transport"
 	"google.golang.org/api/youtube/v3"
+
+	"github.com/GetLoloed/Go-ytb-comments/auth"
+	"github.com/GetLoloed/Go-ytb-comments/comments"
+	"github.com/GetLoloed/Go-ytb-comments/quota"
+	"github.com/GetLoloed/Go-ytb-comments/state"
+	"github.com/GetLoloed/Go-ytb-comments/videos"
+	"github.com/GetLoloed/Go-ytb-comments/ytutil"
 )
 
 const (
@@ -28,18 +36,22 @@ const (
 	invalidURLMsg           = "Invalid YouTube URL"
 	errorAPICallMsg         = "Error during API search call: %v"
 	errorWritingFileMsg     = "Error writing to file: %v"
-)
 
-func getVideoId(videoUrl string) (string, error) {
-	u, err := url.Parse(videoUrl)
-	if err != nil {
-		return "", fmt.Errorf("failed to parse URL: %w", err)
-	}
-	return u.Query().Get("v"), nil
-}
+	authModeAPIKey = "apikey"
+	authModeOAuth  = "oauth"
+)
 
-func getComments(ctx context.Context, videoUrls []string, maxComments int64, developerKey string) {
+// getComments fans out comment fetching across videoUrls. When keyPool is
+// non-nil it is used to pick (and rotate between) developer keys per
+// attempt instead of the single client; pass a nil keyPool when client
+// already carries its own credentials (e.g. an OAuth2 client). It returns
+// the subset of videoUrls that failed to sync, so callers that checkpoint
+// progress (e.g. the channel cursor) can tell which videos still need a
+// retry.
+func getComments(ctx context.Context, videoUrls []string, maxComments int64, client *http.Client, keyPool *quota.KeyPool) []string {
 	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var failed []string
 	limiter := rate.NewLimiter(rate.Every(time.Second), 1)
 
 	for _, videoUrl := range videoUrls {
@@ -47,61 +59,518 @@ func getComments(ctx context.Context, videoUrls []string, maxComments int64, dev
 		go func(videoUrl string) {
 			defer wg.Done()
 
+			markFailed := func() {
+				mu.Lock()
+				failed = append(failed, videoUrl)
+				mu.Unlock()
+			}
+
 			if err := limiter.Wait(ctx); err != nil {
 				color.Red("Rate limit error: %v", err)
+				markFailed()
 				return
 			}
 
+			expBackoff := backoff.NewExponentialBackOff()
+
 			operation := func() error {
-				client := &http.Client{
-					Transport: &transport.APIKey{Key: developerKey},
+				requestClient := client
+				activeKey := ""
+
+				if keyPool != nil {
+					key, err := keyPool.Acquire()
+					if err != nil {
+						return err
+					}
+					activeKey = key
+					requestClient = &http.Client{Transport: &transport.APIKey{Key: key}}
 				}
 
-				service, err := youtube.New(client)
+				service, err := youtube.New(requestClient)
 				if err != nil {
 					return fmt.Errorf("error creating new YouTube client: %w", err)
 				}
 
-				videoId, err := getVideoId(videoUrl)
+				videoId, _, err := ytutil.ParseVideoID(videoUrl)
 				if err != nil {
 					return fmt.Errorf("%s: %w", invalidURLMsg, err)
 				}
 
-				filename := fmt.Sprintf("%s_%s.txt", defaultCommentsFileName, videoId)
-				file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+				commentWriter, err := comments.WriterForFormat(outputFormat)
 				if err != nil {
-					return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+					return err
 				}
-				defer file.Close()
 
-				writer := bufio.NewWriter(file)
-				defer writer.Flush()
+				filename := fmt.Sprintf("%s_%s.%s", defaultCommentsFileName, videoId, fileExtensionForFormat(outputFormat))
+
+				if refreshFlag {
+					err = refreshComments(service, videoId, commentWriter, filename)
+				} else {
+					err = fetchAndPersistComments(service, videoId, maxComments, commentWriter, filename)
+				}
 
-				call := service.CommentThreads.List([]string{"snippet"}).VideoId(videoId).MaxResults(maxComments)
-				response, err := call.Do()
 				if err != nil {
+					if keyPool != nil && quota.IsQuotaError(err) {
+						keyPool.CoolDown(activeKey)
+						expBackoff.Reset()
+					}
 					return fmt.Errorf("%s: %w", errorAPICallMsg, err)
 				}
 
-				for _, item := range response.Items {
-					comment := item.Snippet.TopLevelComment
-					_, err := fmt.Fprintf(writer, "Comment from %s: %s\n", comment.Snippet.AuthorDisplayName, comment.Snippet.TextDisplay)
-					if err != nil {
-						return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
-					}
+				if keyPool != nil {
+					keyPool.Debit(activeKey, quota.CommentThreadsListCost)
 				}
 
 				return nil
 			}
 
-			err := backoff.Retry(operation, backoff.NewExponentialBackOff())
+			err := backoff.Retry(operation, expBackoff)
 			if err != nil {
 				color.Red("Failed to retrieve comments: %v", err)
+				markFailed()
 			}
 		}(videoUrl)
 	}
 
 	wg.Wait()
+
+	return failed
+}
+
+const maxResultsPerPage = 100
+
+// fetchAndPersistComments opens filename for appending and delegates to
+// fetchAndWriteComments, flushing the buffered writer once fetching
+// finishes (or fails).
+func fetchAndPersistComments(service *youtube.Service, videoId string, maxComments int64, commentWriter comments.Writer, filename string) error {
+	file, err := os.OpenFile(filename, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+	}
+	defer file.Close()
+
+	writer := bufio.NewWriter(file)
+	defer writer.Flush()
+
+	return fetchAndWriteComments(service, videoId, maxComments, commentWriter, writer)
+}
+
+// fetchAndWriteComments resumes from videoId's saved state (if any),
+// walking pages of top-level comment threads, skipping any comment
+// already written by a previous run, and fetching every reply for
+// threads that have any. For incremental formats (txt, jsonl), each
+// page's newly-seen comments are written as soon as they're fetched; for
+// formats that only form a valid document from a single call (json,
+// csv), newly-seen comments are buffered and written once at the end.
+// The state is saved (atomically) only once the comments it marks as
+// seen are on disk: after every page for incremental formats, and after
+// the final write for buffered ones. An interrupted run can therefore
+// resume without duplicating or skipping anything already on disk.
+func fetchAndWriteComments(service *youtube.Service, videoId string, maxComments int64, commentWriter comments.Writer, w *bufio.Writer) error {
+	st, err := state.Load(videoId)
+	if err != nil {
+		return err
+	}
+
+	var collected int64
+	var buffered []comments.Comment
+	pageToken := st.NextPageToken
+
+	for collected < maxComments {
+		remaining := maxComments - collected
+		pageSize := remaining
+		if pageSize > maxResultsPerPage {
+			pageSize = maxResultsPerPage
+		}
+
+		call := service.CommentThreads.List([]string{"snippet"}).VideoId(videoId).MaxResults(pageSize)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		response, err := call.Do()
+		if err != nil {
+			return err
+		}
+
+		var newComments []comments.Comment
+		for _, item := range response.Items {
+			topLevel := item.Snippet.TopLevelComment
+			if st.Seen(topLevel.Id) {
+				continue
+			}
+
+			comment := comments.Comment{
+				ID:          topLevel.Id,
+				Author:      topLevel.Snippet.AuthorDisplayName,
+				Text:        topLevel.Snippet.TextDisplay,
+				PublishedAt: parsePublishedAt(topLevel.Snippet.PublishedAt),
+				LikeCount:   topLevel.Snippet.LikeCount,
+			}
+
+			if item.Snippet.TotalReplyCount > 0 {
+				replies, err := fetchReplies(service, topLevel.Id)
+				if err != nil {
+					return err
+				}
+				comment.Replies = replies
+			}
+
+			newComments = append(newComments, comment)
+			st.MarkSeen(topLevel.Id)
+			for _, reply := range comment.Replies {
+				st.MarkReplySeen(reply.ID, topLevel.Id)
+			}
+
+			collected++
+			if collected >= maxComments {
+				break
+			}
+		}
+
+		if len(newComments) > 0 {
+			if commentWriter.Incremental() {
+				if err := commentWriter.Write(w, newComments); err != nil {
+					return err
+				}
+			} else {
+				buffered = append(buffered, newComments...)
+			}
+		}
+
+		pageToken = response.NextPageToken
+		st.NextPageToken = pageToken
+
+		if err := w.Flush(); err != nil {
+			return err
+		}
+		if commentWriter.Incremental() {
+			if err := st.Save(); err != nil {
+				return err
+			}
+		}
+
+		if pageToken == "" {
+			break
+		}
+	}
+
+	if len(buffered) > 0 {
+		if err := commentWriter.Write(w, buffered); err != nil {
+			return err
+		}
+		if err := w.Flush(); err != nil {
+			return err
+		}
+	}
+
+	if !commentWriter.Incremental() {
+		return st.Save()
+	}
+
+	return nil
+}
+
+// refreshComments re-fetches every comment already recorded in videoId's
+// state, in batches, and replaces filename with the refreshed text and
+// like counts - without re-walking pages or downloading anything new.
+// The flat set of re-fetched comments is reassembled into the same
+// nested tree fetchAndWriteComments originally produced, using the
+// parent/child linkage state recorded for replies, before being handed
+// to commentWriter. The refreshed set is written to a temp file and
+// renamed into place (the same atomic pattern state.Save uses) so the
+// replacement is all-or-nothing: a run that fails partway through
+// leaves the previous file untouched instead of appending a second,
+// possibly-stale copy of every comment after the original.
+func refreshComments(service *youtube.Service, videoId string, commentWriter comments.Writer, filename string) error {
+	st, err := state.Load(videoId)
+	if err != nil {
+		return err
+	}
+
+	ids := make([]string, 0, len(st.SeenCommentIDs))
+	for id := range st.SeenCommentIDs {
+		ids = append(ids, id)
+	}
+
+	var refreshed []comments.Comment
+
+	const batchSize = 50
+	for start := 0; start < len(ids); start += batchSize {
+		end := start + batchSize
+		if end > len(ids) {
+			end = len(ids)
+		}
+		batch := ids[start:end]
+
+		response, err := service.Comments.List([]string{"snippet"}).Id(strings.Join(batch, ",")).Do()
+		if err != nil {
+			return err
+		}
+
+		for _, item := range response.Items {
+			refreshed = append(refreshed, comments.Comment{
+				ID:          item.Id,
+				Author:      item.Snippet.AuthorDisplayName,
+				Text:        item.Snippet.TextDisplay,
+				PublishedAt: parsePublishedAt(item.Snippet.PublishedAt),
+				LikeCount:   item.Snippet.LikeCount,
+			})
+		}
+	}
+
+	refreshed = nestComments(refreshed, st.ParentOf)
+
+	tmp := filename + ".tmp"
+	file, err := os.OpenFile(tmp, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+	}
+
+	writer := bufio.NewWriter(file)
+	if err := commentWriter.Write(writer, refreshed); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+	}
+	if err := writer.Flush(); err != nil {
+		file.Close()
+		return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+	}
+	if err := file.Close(); err != nil {
+		return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+	}
+
+	if err := os.Rename(tmp, filename); err != nil {
+		return fmt.Errorf("%s: %w", errorWritingFileMsg, err)
+	}
+
+	return nil
+}
+
+// nestComments reassembles flat (a set of comments fetched without
+// regard to thread structure) into top-level comments with their
+// replies nested underneath, using parentOf to look up each comment's
+// parent ID. A comment recorded in parentOf whose parent isn't present
+// in flat (e.g. the parent comment was deleted since the last run) is
+// kept as top-level rather than silently dropped.
+func nestComments(flat []comments.Comment, parentOf map[string]string) []comments.Comment {
+	byID := make(map[string]*comments.Comment, len(flat))
+	for i := range flat {
+		byID[flat[i].ID] = &flat[i]
+	}
+
+	attachedAsReply := make(map[string]bool, len(flat))
+	for i := range flat {
+		parentID, isReply := parentOf[flat[i].ID]
+		if !isReply {
+			continue
+		}
+		if parent, ok := byID[parentID]; ok {
+			parent.Replies = append(parent.Replies, flat[i])
+			attachedAsReply[flat[i].ID] = true
+		}
+	}
+
+	var topLevel []comments.Comment
+	for i := range flat {
+		if attachedAsReply[flat[i].ID] {
+			continue
+		}
+		topLevel = append(topLevel, *byID[flat[i].ID])
+	}
+
+	return topLevel
+}
+
+// fetchReplies walks every page of replies under parentId.
+func fetchReplies(service *youtube.Service, parentId string) ([]comments.Comment, error) {
+	var result []comments.Comment
+	pageToken := ""
+
+	for {
+		call := service.Comments.List([]string{"snippet"}).ParentId(parentId)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		response, err := call.Do()
+		if err != nil {
+			return nil, err
+		}
+
+		for _, item := range response.Items {
+			result = append(result, comments.Comment{
+				ID:          item.Id,
+				Author:      item.Snippet.AuthorDisplayName,
+				Text:        item.Snippet.TextDisplay,
+				PublishedAt: parsePublishedAt(item.Snippet.PublishedAt),
+				LikeCount:   item.Snippet.LikeCount,
+			})
+		}
+
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	return result, nil
+}
+
+func parsePublishedAt(raw string) time.Time {
+	published, err := time.Parse(time.RFC3339, raw)
+	if err != nil {
+		return time.Time{}
+	}
+	return published
+}
+
+func fileExtensionForFormat(format string) string {
+	if format == "" {
+		return "txt"
+	}
+	return format
+}
+
+// GetCommentsForChannel enumerates every video uploaded by channelRef
+// (a channel URL, @handle, or bare ID), optionally restricted to videos
+// published within since, and fetches comments for each one. It persists
+// a per-channel cursor so a later call with since <= 0 still only pulls
+// videos published after the last successful sync. The cursor only
+// advances when every discovered video synced successfully, so a video
+// that fails (quota exhaustion, a network blip, ...) is retried on the
+// next run instead of being silently skipped forever. The cursor is set
+// to the latest publishedAt actually observed among the synced videos,
+// not wall-clock time, so a video whose appearance in the uploads
+// playlist lags its publishedAt can't be skipped permanently by a
+// cursor that raced ahead of it.
+func GetCommentsForChannel(ctx context.Context, channelRef string, maxComments int64, client *http.Client, keyPool *quota.KeyPool, since time.Duration) error {
+	service, err := discoveryService(client, keyPool)
+	if err != nil {
+		return err
+	}
+
+	channelID, err := videos.ResolveChannelID(service, channelRef)
+	if err != nil {
+		return err
+	}
+
+	sinceTime, err := resolveSince(since, channelID)
+	if err != nil {
+		return err
+	}
+
+	uploadsPlaylistID, err := videos.UploadsPlaylistID(service, channelID)
+	if err != nil {
+		return err
+	}
+
+	videoList, err := videos.ListPlaylistVideos(service, uploadsPlaylistID, sinceTime)
+	if err != nil {
+		return err
+	}
+
+	failed := getComments(ctx, videoIDsOf(videoList), maxComments, client, keyPool)
+	if len(failed) > 0 {
+		color.Red("%d video(s) failed to sync; leaving cursor unchanged so they're retried next run", len(failed))
+		return nil
+	}
+
+	if len(videoList) == 0 {
+		return nil
+	}
+
+	return videos.SaveCursor(channelID, clampToNow(maxPublishedAt(videoList)))
+}
+
+// maxPublishedAt returns the latest PublishedAt among videoList.
+func maxPublishedAt(videoList []videos.Video) time.Time {
+	var max time.Time
+	for _, video := range videoList {
+		if video.PublishedAt.After(max) {
+			max = video.PublishedAt
+		}
+	}
+	return max
+}
+
+// clampToNow caps t at the current time, guarding against a video whose
+// publishedAt is (erroneously) in the future advancing the cursor past
+// videos that haven't been discovered yet.
+func clampToNow(t time.Time) time.Time {
+	if now := time.Now(); t.After(now) {
+		return now
+	}
+	return t
+}
+
+// GetCommentsForPlaylist enumerates every video in playlistID, optionally
+// restricted to videos published within since, and fetches comments for
+// each one.
+func GetCommentsForPlaylist(ctx context.Context, playlistID string, maxComments int64, client *http.Client, keyPool *quota.KeyPool, since time.Duration) error {
+	service, err := discoveryService(client, keyPool)
+	if err != nil {
+		return err
+	}
+
+	sinceTime := time.Time{}
+	if since > 0 {
+		sinceTime = time.Now().Add(-since)
+	}
+
+	videoList, err := videos.ListPlaylistVideos(service, playlistID, sinceTime)
+	if err != nil {
+		return err
+	}
+
+	getComments(ctx, videoIDsOf(videoList), maxComments, client, keyPool)
+
+	return nil
+}
+
+// discoveryService builds the YouTube client used for the one-off channel
+// and playlist lookups, acquiring a key from keyPool when one was
+// configured instead of relying on a single fixed client.
+func discoveryService(client *http.Client, keyPool *quota.KeyPool) (*youtube.Service, error) {
+	if keyPool != nil {
+		key, err := keyPool.Acquire()
+		if err != nil {
+			return nil, err
+		}
+		client = &http.Client{Transport: &transport.APIKey{Key: key}}
+	}
+
+	service, err := youtube.New(client)
+	if err != nil {
+		return nil, fmt.Errorf("error creating new YouTube client: %w", err)
+	}
+
+	return service, nil
+}
+
+func resolveSince(since time.Duration, channelID string) (time.Time, error) {
+	sinceTime := time.Time{}
+	if since > 0 {
+		sinceTime = time.Now().Add(-since)
+	}
+
+	cursor, err := videos.LoadCursor(channelID)
+	if err != nil {
+		return time.Time{}, err
+	}
+
+	if cursor.After(sinceTime) {
+		return cursor, nil
+	}
+	return sinceTime, nil
+}
+
+func videoIDsOf(videoList []videos.Video) []string {
+	videoIDs := make([]string, len(videoList))
+	for i, video := range videoList {
+		videoIDs[i] = video.ID
+	}
+	return videoIDs
 }
 
 func getDeveloperKey() string {
@@ -129,6 +598,56 @@ func getDeveloperKey() string {
 	return viper.GetString("developerKey")
 }
 
+// getDeveloperKeys returns the configured developerKeys list, falling
+// back to the single legacy developerKey entry (prompting to create one
+// if neither is configured yet).
+func getDeveloperKeys() []string {
+	if keys := viper.GetStringSlice("developerKeys"); len(keys) > 0 {
+		return keys
+	}
+	return []string{getDeveloperKey()}
+}
+
+func getAuthMode() string {
+	authMode := viper.GetString("authMode")
+	if authMode == "" {
+		return authModeAPIKey
+	}
+	return authMode
+}
+
+// newYouTubeClientSource builds the means of authenticating to the
+// YouTube API, honoring the configured authMode. In oauth mode it returns
+// a single client that exchanges (or reuses a cached) user token read
+// from clientSecretFile, with no key pool. In apikey mode it instead
+// returns a KeyPool over the configured developerKeys, so callers can
+// rotate across keys as quota runs out.
+func newYouTubeClientSource(ctx context.Context) (*http.Client, *quota.KeyPool, error) {
+	viper.SetConfigName("config")
+	viper.AddConfigPath(".")
+	// A missing config file is handled by getDeveloperKey, which prompts
+	// for a key and creates it.
+	_ = viper.ReadInConfig()
+
+	if getAuthMode() != authModeOAuth {
+		return nil, quota.NewKeyPool(getDeveloperKeys()), nil
+	}
+
+	clientSecretFile := viper.GetString("clientSecretFile")
+	secret, err := os.ReadFile(clientSecretFile)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to read client secret file: %w", err)
+	}
+
+	config, err := google.ConfigFromJSON(secret, youtube.YoutubeForceSslScope)
+	if err != nil {
+		return nil, nil, fmt.Errorf("unable to parse client secret file: %w", err)
+	}
+
+	client, err := auth.GetClient(ctx, config)
+	return client, nil, err
+}
+
 func getNumberOfComments() int {
 	for {
 		color.Cyan("Enter the number of comments to retrieve: ")
@@ -163,21 +682,70 @@ func readInput() string {
 	return scanner.Text()
 }
 
+var outputFormat string
+var sinceFlag time.Duration
+var refreshFlag bool
+
 func main() {
-	developerKey := getDeveloperKey()
+	flag.StringVar(&outputFormat, "format", "txt", "output format: txt, json, jsonl, or csv")
+	flag.DurationVar(&sinceFlag, "since", 0, "only fetch comments for videos published within this long ago, e.g. 168h (channel/playlist mode only)")
+	flag.BoolVar(&refreshFlag, "refresh", false, "re-fetch previously seen comments to update edited text and like counts, instead of fetching new ones")
+	flag.Parse()
+
 	ctx := context.Background()
 
-	for {
-		maxComments := getNumberOfComments()
+	client, keyPool, err := newYouTubeClientSource(ctx)
+	if err != nil {
+		color.Red("Error creating YouTube client source: %v", err)
+		return
+	}
 
-		color.Cyan("Enter the YouTube video URL: ")
-		videoUrl := readInput()
-		videoUrls := []string{videoUrl}
+	for {
+		maxComments := int64(getNumberOfComments())
 
-		getComments(ctx, videoUrls, int64(maxComments), developerKey)
+		switch getScrapeMode() {
+		case scrapeModeVideo:
+			color.Cyan("Enter the YouTube video URL: ")
+			videoUrl := readInput()
+			getComments(ctx, []string{videoUrl}, maxComments, client, keyPool)
+		case scrapeModeChannel:
+			color.Cyan("Enter the YouTube channel URL or @handle: ")
+			channelRef := readInput()
+			if err := GetCommentsForChannel(ctx, channelRef, maxComments, client, keyPool, sinceFlag); err != nil {
+				color.Red("Failed to retrieve channel comments: %v", err)
+			}
+		case scrapeModePlaylist:
+			color.Cyan("Enter the YouTube playlist ID: ")
+			playlistID := readInput()
+			if err := GetCommentsForPlaylist(ctx, playlistID, maxComments, client, keyPool, sinceFlag); err != nil {
+				color.Red("Failed to retrieve playlist comments: %v", err)
+			}
+		}
 
 		if !askToContinue() {
 			return
 		}
 	}
 }
+
+const (
+	scrapeModeVideo = iota
+	scrapeModeChannel
+	scrapeModePlaylist
+)
+
+func getScrapeMode() int {
+	for {
+		color.Cyan("Scrape a (1) single video, (2) channel, or (3) playlist? ")
+		switch readInput() {
+		case "1":
+			return scrapeModeVideo
+		case "2":
+			return scrapeModeChannel
+		case "3":
+			return scrapeModePlaylist
+		default:
+			color.Red("Invalid input. Please enter 1, 2, or 3.")
+		}
+	}
+}
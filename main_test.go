@@ -0,0 +1,47 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/GetLoloed/Go-ytb-comments/comments"
+)
+
+func TestNestCommentsReassemblesReplies(t *testing.T) {
+	flat := []comments.Comment{
+		{ID: "c1", Author: "Alice", Text: "Great video!"},
+		{ID: "c1-r1", Author: "Bob", Text: "Agreed"},
+		{ID: "c2", Author: "Carol", Text: "First!"},
+	}
+	parentOf := map[string]string{"c1-r1": "c1"}
+
+	got := nestComments(flat, parentOf)
+
+	want := []comments.Comment{
+		{ID: "c1", Author: "Alice", Text: "Great video!", Replies: []comments.Comment{
+			{ID: "c1-r1", Author: "Bob", Text: "Agreed"},
+		}},
+		{ID: "c2", Author: "Carol", Text: "First!"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nestComments(...) = %+v, want %+v", got, want)
+	}
+}
+
+func TestNestCommentsKeepsOrphanedReplyAsTopLevel(t *testing.T) {
+	flat := []comments.Comment{
+		{ID: "c1-r1", Author: "Bob", Text: "Agreed"},
+	}
+	parentOf := map[string]string{"c1-r1": "c1"}
+
+	got := nestComments(flat, parentOf)
+
+	want := []comments.Comment{
+		{ID: "c1-r1", Author: "Bob", Text: "Agreed"},
+	}
+
+	if !reflect.DeepEqual(got, want) {
+		t.Errorf("nestComments(...) = %+v, want %+v; expected orphaned reply to survive as top-level", got, want)
+	}
+}
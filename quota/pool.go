@@ -0,0 +1,109 @@
+// Package quota manages a pool of YouTube Data API developer keys,
+// spreading requests across whichever key has spent the least quota and
+// benching keys that come back with a quotaExceeded error until the next
+// daily reset.
+package quota
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"sync"
+	"time"
+
+	"google.golang.org/api/googleapi"
+)
+
+// CommentThreadsListCost is the quota cost, in units, of a single
+// CommentThreads.list call.
+const CommentThreadsListCost = 1
+
+// KeyPool hands out the least-loaded developer key that isn't cooling
+// down, and tracks per-key spend and cooldowns.
+type KeyPool struct {
+	mu       sync.Mutex
+	keys     []string
+	cost     map[string]int
+	cooldown map[string]time.Time
+}
+
+// NewKeyPool creates a pool over the given developer keys.
+func NewKeyPool(keys []string) *KeyPool {
+	return &KeyPool{
+		keys:     keys,
+		cost:     make(map[string]int, len(keys)),
+		cooldown: make(map[string]time.Time, len(keys)),
+	}
+}
+
+// Acquire returns the least-loaded key that isn't currently cooling down.
+func (p *KeyPool) Acquire() (string, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	now := time.Now()
+	best := ""
+	bestCost := -1
+
+	for _, key := range p.keys {
+		if until, cooling := p.cooldown[key]; cooling && now.Before(until) {
+			continue
+		}
+		if bestCost == -1 || p.cost[key] < bestCost {
+			best, bestCost = key, p.cost[key]
+		}
+	}
+
+	if best == "" {
+		return "", fmt.Errorf("no developer keys available: all %d are cooling down", len(p.keys))
+	}
+
+	return best, nil
+}
+
+// Debit records that key was just charged cost quota units.
+func (p *KeyPool) Debit(key string, cost int) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cost[key] += cost
+}
+
+// CoolDown benches key until the next YouTube quota reset (midnight
+// Pacific time).
+func (p *KeyPool) CoolDown(key string) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	p.cooldown[key] = nextMidnightPacific(time.Now())
+}
+
+func nextMidnightPacific(from time.Time) time.Time {
+	location, err := time.LoadLocation("America/Los_Angeles")
+	if err != nil {
+		location = time.UTC
+	}
+
+	local := from.In(location)
+	return time.Date(local.Year(), local.Month(), local.Day(), 0, 0, 0, 0, location).AddDate(0, 0, 1)
+}
+
+// IsQuotaError reports whether err is a googleapi error caused by a
+// per-key quota or rate limit, as opposed to a transient 5xx/network
+// failure that should instead be handled by exponential backoff.
+func IsQuotaError(err error) bool {
+	var apiErr *googleapi.Error
+	if !errors.As(err, &apiErr) {
+		return false
+	}
+
+	if apiErr.Code == http.StatusTooManyRequests {
+		return true
+	}
+
+	for _, e := range apiErr.Errors {
+		if e.Reason == "quotaExceeded" || e.Reason == "rateLimitExceeded" {
+			return true
+		}
+	}
+
+	return false
+}
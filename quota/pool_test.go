@@ -0,0 +1,84 @@
+package quota
+
+import (
+	"testing"
+
+	"google.golang.org/api/googleapi"
+)
+
+func TestKeyPoolPrefersLeastLoadedKey(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a", "key-b"})
+	pool.Debit("key-a", 10)
+
+	got, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire returned unexpected error: %v", err)
+	}
+	if got != "key-b" {
+		t.Errorf("Acquire() = %q, want %q", got, "key-b")
+	}
+}
+
+func TestKeyPoolSkipsCoolingKeys(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a", "key-b"})
+	pool.CoolDown("key-a")
+
+	got, err := pool.Acquire()
+	if err != nil {
+		t.Fatalf("Acquire returned unexpected error: %v", err)
+	}
+	if got != "key-b" {
+		t.Errorf("Acquire() = %q, want %q", got, "key-b")
+	}
+}
+
+func TestKeyPoolErrorsWhenAllKeysCooling(t *testing.T) {
+	pool := NewKeyPool([]string{"key-a"})
+	pool.CoolDown("key-a")
+
+	if _, err := pool.Acquire(); err == nil {
+		t.Error("Acquire() expected an error when all keys are cooling down, got nil")
+	}
+}
+
+func TestIsQuotaError(t *testing.T) {
+	tests := []struct {
+		name string
+		err  error
+		want bool
+	}{
+		{
+			name: "quotaExceeded",
+			err:  &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "quotaExceeded"}}},
+			want: true,
+		},
+		{
+			name: "rateLimitExceeded",
+			err:  &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "rateLimitExceeded"}}},
+			want: true,
+		},
+		{
+			name: "too many requests",
+			err:  &googleapi.Error{Code: 429},
+			want: true,
+		},
+		{
+			name: "unrelated forbidden",
+			err:  &googleapi.Error{Code: 403, Errors: []googleapi.ErrorItem{{Reason: "forbidden"}}},
+			want: false,
+		},
+		{
+			name: "server error",
+			err:  &googleapi.Error{Code: 500},
+			want: false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := IsQuotaError(tt.err); got != tt.want {
+				t.Errorf("IsQuotaError(%v) = %v, want %v", tt.err, got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+// Package state persists per-video scrape progress to a JSON sidecar
+// file, so a later run can resume from where the last one left off
+// instead of re-downloading and re-appending everything.
+package state
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+)
+
+// State tracks the comment IDs already written for a video, the parent
+// thread each reply belongs to, and the pagination token to resume from.
+type State struct {
+	VideoID        string            `json:"videoId"`
+	SeenCommentIDs map[string]bool   `json:"seenCommentIds"`
+	ParentOf       map[string]string `json:"parentOf"`
+	NextPageToken  string            `json:"nextPageToken"`
+}
+
+func fileName(videoID string) string {
+	return fmt.Sprintf("comments_%s.state.json", videoID)
+}
+
+// Load reads the saved state for videoID, or returns a fresh, empty
+// State if none has been saved yet.
+func Load(videoID string) (*State, error) {
+	data, err := os.ReadFile(fileName(videoID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return &State{VideoID: videoID, SeenCommentIDs: map[string]bool{}, ParentOf: map[string]string{}}, nil
+		}
+		return nil, fmt.Errorf("error reading state for video %q: %w", videoID, err)
+	}
+
+	var s State
+	if err := json.Unmarshal(data, &s); err != nil {
+		return nil, fmt.Errorf("error parsing state for video %q: %w", videoID, err)
+	}
+	if s.SeenCommentIDs == nil {
+		s.SeenCommentIDs = map[string]bool{}
+	}
+	if s.ParentOf == nil {
+		s.ParentOf = map[string]string{}
+	}
+
+	return &s, nil
+}
+
+// Save atomically persists s by writing to a temp file alongside the
+// final path and renaming it into place.
+func (s *State) Save() error {
+	data, err := json.Marshal(s)
+	if err != nil {
+		return fmt.Errorf("error encoding state for video %q: %w", s.VideoID, err)
+	}
+
+	final := fileName(s.VideoID)
+	tmp := final + ".tmp"
+
+	if err := os.WriteFile(tmp, data, 0644); err != nil {
+		return fmt.Errorf("error writing state for video %q: %w", s.VideoID, err)
+	}
+	if err := os.Rename(tmp, final); err != nil {
+		return fmt.Errorf("error saving state for video %q: %w", s.VideoID, err)
+	}
+
+	return nil
+}
+
+// Seen reports whether commentID has already been written.
+func (s *State) Seen(commentID string) bool {
+	return s.SeenCommentIDs[commentID]
+}
+
+// MarkSeen records commentID as written.
+func (s *State) MarkSeen(commentID string) {
+	s.SeenCommentIDs[commentID] = true
+}
+
+// MarkReplySeen records commentID as written and remembers that it is a
+// reply nested under parentID, so a later --refresh can reassemble the
+// original comment tree instead of flattening every reply back out as a
+// top-level comment.
+func (s *State) MarkReplySeen(commentID, parentID string) {
+	s.MarkSeen(commentID)
+	s.ParentOf[commentID] = parentID
+}
@@ -0,0 +1,69 @@
+package state
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestSaveAndLoadRoundTrip(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+
+	st := &State{VideoID: "abc123", SeenCommentIDs: map[string]bool{}, ParentOf: map[string]string{}, NextPageToken: "page-2"}
+	st.MarkSeen("c1")
+	st.MarkReplySeen("c1-r1", "c1")
+
+	if err := st.Save(); err != nil {
+		t.Fatalf("Save returned unexpected error: %v", err)
+	}
+
+	if _, err := os.Stat(filepath.Join(dir, "comments_abc123.state.json.tmp")); !os.IsNotExist(err) {
+		t.Error("Save left a .tmp file behind instead of renaming it")
+	}
+
+	loaded, err := Load("abc123")
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+
+	if loaded.NextPageToken != "page-2" {
+		t.Errorf("NextPageToken = %q, want %q", loaded.NextPageToken, "page-2")
+	}
+	if !loaded.Seen("c1") || !loaded.Seen("c1-r1") {
+		t.Error("expected c1 and c1-r1 to be marked seen after reload")
+	}
+	if loaded.Seen("c3") {
+		t.Error("did not expect c3 to be marked seen")
+	}
+	if loaded.ParentOf["c1-r1"] != "c1" {
+		t.Errorf("ParentOf[%q] = %q, want %q", "c1-r1", loaded.ParentOf["c1-r1"], "c1")
+	}
+}
+
+func TestLoadMissingStateReturnsEmpty(t *testing.T) {
+	dir := t.TempDir()
+	cwd, err := os.Getwd()
+	if err != nil {
+		t.Fatalf("Getwd failed: %v", err)
+	}
+	defer os.Chdir(cwd)
+	if err := os.Chdir(dir); err != nil {
+		t.Fatalf("Chdir failed: %v", err)
+	}
+
+	st, err := Load("does-not-exist")
+	if err != nil {
+		t.Fatalf("Load returned unexpected error: %v", err)
+	}
+	if st.NextPageToken != "" || len(st.SeenCommentIDs) != 0 {
+		t.Errorf("expected empty state, got %+v", st)
+	}
+}
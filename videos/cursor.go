@@ -0,0 +1,50 @@
+package videos
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+)
+
+type cursor struct {
+	LastSyncedAt time.Time `json:"lastSyncedAt"`
+}
+
+func cursorFileName(channelID string) string {
+	return fmt.Sprintf("channel_%s.cursor.json", channelID)
+}
+
+// LoadCursor returns the last-synced timestamp recorded for channelID, or
+// the zero time if no cursor has been saved yet.
+func LoadCursor(channelID string) (time.Time, error) {
+	data, err := os.ReadFile(cursorFileName(channelID))
+	if err != nil {
+		if os.IsNotExist(err) {
+			return time.Time{}, nil
+		}
+		return time.Time{}, fmt.Errorf("error reading cursor for channel %q: %w", channelID, err)
+	}
+
+	var c cursor
+	if err := json.Unmarshal(data, &c); err != nil {
+		return time.Time{}, fmt.Errorf("error parsing cursor for channel %q: %w", channelID, err)
+	}
+
+	return c.LastSyncedAt, nil
+}
+
+// SaveCursor records syncedAt as the last-synced timestamp for channelID,
+// so the next run only pulls videos published after it.
+func SaveCursor(channelID string, syncedAt time.Time) error {
+	data, err := json.Marshal(cursor{LastSyncedAt: syncedAt})
+	if err != nil {
+		return fmt.Errorf("error encoding cursor for channel %q: %w", channelID, err)
+	}
+
+	if err := os.WriteFile(cursorFileName(channelID), data, 0644); err != nil {
+		return fmt.Errorf("error writing cursor for channel %q: %w", channelID, err)
+	}
+
+	return nil
+}
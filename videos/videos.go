@@ -0,0 +1,106 @@
+// Package videos resolves a channel or playlist reference into the list
+// of video IDs it contains, so callers can fan comment fetching out
+// across an entire channel instead of a single URL at a time.
+package videos
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"time"
+
+	"google.golang.org/api/youtube/v3"
+)
+
+var handlePattern = regexp.MustCompile(`youtube\.com/@([^/?&]+)`)
+var channelIDPattern = regexp.MustCompile(`youtube\.com/channel/([^/?&]+)`)
+
+// Video is a single video discovered while walking a channel or playlist.
+type Video struct {
+	ID          string
+	PublishedAt time.Time
+}
+
+// ResolveChannelID turns a channel URL, @handle, or bare channel ID into
+// a canonical channel ID.
+func ResolveChannelID(service *youtube.Service, channelRef string) (string, error) {
+	if match := channelIDPattern.FindStringSubmatch(channelRef); match != nil {
+		return match[1], nil
+	}
+
+	handle := ""
+	if match := handlePattern.FindStringSubmatch(channelRef); match != nil {
+		handle = match[1]
+	} else if strings.HasPrefix(channelRef, "@") {
+		handle = strings.TrimPrefix(channelRef, "@")
+	}
+
+	if handle != "" {
+		response, err := service.Channels.List([]string{"id"}).ForHandle(handle).Do()
+		if err != nil {
+			return "", fmt.Errorf("error resolving channel handle %q: %w", handle, err)
+		}
+		if len(response.Items) == 0 {
+			return "", fmt.Errorf("no channel found for handle %q", handle)
+		}
+		return response.Items[0].Id, nil
+	}
+
+	return channelRef, nil
+}
+
+// UploadsPlaylistID returns the ID of the "uploads" playlist backing the
+// given channel, which lists every video the channel has published.
+func UploadsPlaylistID(service *youtube.Service, channelID string) (string, error) {
+	response, err := service.Channels.List([]string{"contentDetails"}).Id(channelID).Do()
+	if err != nil {
+		return "", fmt.Errorf("error fetching channel %q: %w", channelID, err)
+	}
+	if len(response.Items) == 0 {
+		return "", fmt.Errorf("no channel found for ID %q", channelID)
+	}
+
+	return response.Items[0].ContentDetails.RelatedPlaylists.Uploads, nil
+}
+
+// ListPlaylistVideos walks every page of playlistID, returning every
+// video published after since (zero value means no lower bound).
+func ListPlaylistVideos(service *youtube.Service, playlistID string, since time.Time) ([]Video, error) {
+	var result []Video
+	pageToken := ""
+
+	for {
+		call := service.PlaylistItems.List([]string{"snippet"}).PlaylistId(playlistID).MaxResults(50)
+		if pageToken != "" {
+			call = call.PageToken(pageToken)
+		}
+
+		response, err := call.Do()
+		if err != nil {
+			return nil, fmt.Errorf("error listing playlist %q: %w", playlistID, err)
+		}
+
+		for _, item := range response.Items {
+			publishedAt, err := time.Parse(time.RFC3339, item.Snippet.PublishedAt)
+			if err != nil {
+				publishedAt = time.Time{}
+			}
+
+			if !since.IsZero() && !publishedAt.After(since) {
+				continue
+			}
+
+			result = append(result, Video{
+				ID:          item.Snippet.ResourceId.VideoId,
+				PublishedAt: publishedAt,
+			})
+		}
+
+		if response.NextPageToken == "" {
+			break
+		}
+		pageToken = response.NextPageToken
+	}
+
+	return result, nil
+}
@@ -0,0 +1,26 @@
+package videos
+
+import "testing"
+
+func TestResolveChannelIDWithoutAPICall(t *testing.T) {
+	tests := []struct {
+		name  string
+		input string
+		want  string
+	}{
+		{"channel URL", "https://www.youtube.com/channel/UC_x5XG1OV2P6uZZ5FSM9Ttw", "UC_x5XG1OV2P6uZZ5FSM9Ttw"},
+		{"bare channel ID", "UC_x5XG1OV2P6uZZ5FSM9Ttw", "UC_x5XG1OV2P6uZZ5FSM9Ttw"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := ResolveChannelID(nil, tt.input)
+			if err != nil {
+				t.Fatalf("ResolveChannelID(%q) returned unexpected error: %v", tt.input, err)
+			}
+			if got != tt.want {
+				t.Errorf("ResolveChannelID(%q) = %q, want %q", tt.input, got, tt.want)
+			}
+		})
+	}
+}
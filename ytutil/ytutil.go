@@ -0,0 +1,41 @@
+// Package ytutil parses YouTube video URLs and bare IDs into the 11
+// character video ID YouTube's API expects.
+package ytutil
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+)
+
+var videoIDPattern = regexp.MustCompile(`(?:youtube\.com/(?:[^/\n\s]+/\S+/|(?:v|e(?:mbed)?|shorts)/|\S*?[?&]v=)|youtu\.be/)([a-zA-Z0-9_-]{11})`)
+
+var bareIDPattern = regexp.MustCompile(`^[a-zA-Z0-9_-]{11}$`)
+
+var timestampPattern = regexp.MustCompile(`[?&](?:t|start)=(\d+)`)
+
+// ParseVideoID extracts the video ID from any supported YouTube URL shape
+// (youtube.com/watch?v=, youtu.be/, /shorts/, /embed/, /v/) or a bare
+// 11-character ID, along with an optional start offset in seconds parsed
+// from a t= or start= query parameter.
+func ParseVideoID(raw string) (id string, startSec int, err error) {
+	if bareIDPattern.MatchString(raw) {
+		return raw, 0, nil
+	}
+
+	match := videoIDPattern.FindStringSubmatch(raw)
+	if match == nil {
+		return "", 0, fmt.Errorf("could not find a video ID in %q", raw)
+	}
+
+	id = match[1]
+
+	if tsMatch := timestampPattern.FindStringSubmatch(raw); tsMatch != nil {
+		startSec, err = strconv.Atoi(tsMatch[1])
+		if err != nil {
+			return "", 0, fmt.Errorf("invalid timestamp in %q: %w", raw, err)
+		}
+	}
+
+	return id, startSec, nil
+}
@@ -0,0 +1,85 @@
+package ytutil
+
+import "testing"
+
+func TestParseVideoID(t *testing.T) {
+	tests := []struct {
+		name      string
+		input     string
+		wantID    string
+		wantStart int
+		wantErr   bool
+	}{
+		{
+			name:   "watch URL",
+			input:  "https://www.youtube.com/watch?v=dQw4w9WgXcQ",
+			wantID: "dQw4w9WgXcQ",
+		},
+		{
+			name:      "watch URL with timestamp",
+			input:     "https://www.youtube.com/watch?v=dQw4w9WgXcQ&t=42",
+			wantID:    "dQw4w9WgXcQ",
+			wantStart: 42,
+		},
+		{
+			name:   "short link",
+			input:  "https://youtu.be/dQw4w9WgXcQ",
+			wantID: "dQw4w9WgXcQ",
+		},
+		{
+			name:      "short link with start",
+			input:     "https://youtu.be/dQw4w9WgXcQ?start=10",
+			wantID:    "dQw4w9WgXcQ",
+			wantStart: 10,
+		},
+		{
+			name:   "shorts URL",
+			input:  "https://www.youtube.com/shorts/dQw4w9WgXcQ",
+			wantID: "dQw4w9WgXcQ",
+		},
+		{
+			name:   "embed URL",
+			input:  "https://www.youtube.com/embed/dQw4w9WgXcQ",
+			wantID: "dQw4w9WgXcQ",
+		},
+		{
+			name:   "v URL",
+			input:  "https://www.youtube.com/v/dQw4w9WgXcQ",
+			wantID: "dQw4w9WgXcQ",
+		},
+		{
+			name:   "bare ID",
+			input:  "dQw4w9WgXcQ",
+			wantID: "dQw4w9WgXcQ",
+		},
+		{
+			name:    "invalid URL",
+			input:   "https://example.com/not-a-video",
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			id, start, err := ParseVideoID(tt.input)
+			if tt.wantErr {
+				if err == nil {
+					t.Fatalf("ParseVideoID(%q) expected an error, got nil", tt.input)
+				}
+				return
+			}
+
+			if err != nil {
+				t.Fatalf("ParseVideoID(%q) returned unexpected error: %v", tt.input, err)
+			}
+
+			if id != tt.wantID {
+				t.Errorf("ParseVideoID(%q) id = %q, want %q", tt.input, id, tt.wantID)
+			}
+
+			if start != tt.wantStart {
+				t.Errorf("ParseVideoID(%q) start = %d, want %d", tt.input, start, tt.wantStart)
+			}
+		})
+	}
+}